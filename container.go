@@ -0,0 +1,206 @@
+package progressbar
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theone-daxia/progressbar/cwriter"
+)
+
+// Container coordinates multiple ProgressBar instances that render to the
+// same terminal. It owns a single goroutine that periodically redraws every
+// bar it manages using ANSI cursor movement, so unrelated goroutines (e.g.
+// one per concurrent download) can each call bar.Add(n) without their
+// output interleaving on screen.
+type Container struct {
+	writer io.Writer
+
+	lock       sync.Mutex
+	bars       []*ProgressBar
+	lastHeight int
+
+	refreshRate time.Duration
+	stopOnce    sync.Once
+	stopCh      chan struct{}
+	doneCh      chan struct{}
+	started     bool
+}
+
+// ContainerOption is the type all container options need to adhere to.
+type ContainerOption func(c *Container)
+
+// ContainerOptionSetRefreshRate sets how often the container redraws its
+// bars. The default is 65ms, matching Default's throttle.
+func ContainerOptionSetRefreshRate(d time.Duration) ContainerOption {
+	return func(c *Container) {
+		c.refreshRate = d
+	}
+}
+
+// NewContainer creates a Container that renders its bars to w.
+func NewContainer(w io.Writer, options ...ContainerOption) *Container {
+	c := &Container{
+		writer:      w,
+		refreshRate: 65 * time.Millisecond,
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	return c
+}
+
+// Add creates a new ProgressBar owned by the container. The bar's own
+// writer is replaced with the container's coordinated output, so it must
+// not be changed via OptionSetWriter. The container's render loop is
+// started on the first call to Add.
+func (c *Container) Add(max int64, opts ...Option) *ProgressBar {
+	opts = append([]Option{OptionSetWriter(io.Discard)}, opts...)
+	bar := NewOptions64(max, opts...)
+	bar.config.useANSICodes = true
+
+	c.lock.Lock()
+	c.bars = append(c.bars, bar)
+	start := !c.started
+	c.started = true
+	c.lock.Unlock()
+
+	if start {
+		go c.renderLoop()
+	}
+
+	return bar
+}
+
+// Remove stops the container from rendering bar on subsequent redraws,
+// whether or not bar has finished or been aborted. It does not change the
+// bar's own state.
+func (c *Container) Remove(bar *ProgressBar) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, b := range c.bars {
+		if b == bar {
+			c.bars = append(c.bars[:i], c.bars[i+1:]...)
+			return
+		}
+	}
+}
+
+// Wait blocks until every bar owned by the container has finished or been
+// aborted, draws the bars one last time, and stops the render loop.
+func (c *Container) Wait() {
+	for {
+		if c.allDone() {
+			break
+		}
+		time.Sleep(c.refreshRate)
+	}
+
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	if c.isStarted() {
+		<-c.doneCh
+	}
+	c.renderAll()
+}
+
+func (c *Container) allDone() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, b := range c.bars {
+		b.lock.Lock()
+		done := b.state.finished || b.state.exit
+		b.lock.Unlock()
+		if !done {
+			return false
+		}
+	}
+	return true
+}
+
+// isStarted reports whether the render loop has been started, i.e.
+// whether Add has been called at least once.
+func (c *Container) isStarted() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.started
+}
+
+// Write writes p to the container's underlying writer, taking care that it
+// is printed above the current stack of progress bars rather than
+// clobbering them.
+func (c *Container) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.clearAllLocked()
+	n, err := c.writer.Write(p)
+	c.lastHeight = 0
+	c.renderAllLocked()
+	return n, err
+}
+
+func (c *Container) renderLoop() {
+	defer close(c.doneCh)
+
+	ticker := time.NewTicker(c.refreshRate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.renderAll()
+		}
+	}
+}
+
+func (c *Container) renderAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.renderAllLocked()
+}
+
+// renderAllLocked redraws every bar in place. It must be called with
+// c.lock held.
+func (c *Container) renderAllLocked() {
+	c.clearAllLocked()
+
+	var sb strings.Builder
+	for _, bar := range c.bars {
+		bar.lock.Lock()
+		bar.render()
+		line := bar.state.rendered
+		bar.lock.Unlock()
+
+		sb.WriteString(strings.TrimPrefix(line, "\r"))
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprint(c.writer, sb.String())
+	c.lastHeight = len(c.bars)
+}
+
+// clearAllLocked erases the block of lines written by the previous
+// renderAllLocked call. It must be called with c.lock held.
+func (c *Container) clearAllLocked() {
+	if c.lastHeight == 0 {
+		return
+	}
+	if f, ok := c.writer.(*os.File); ok {
+		cwriter.New(f).ClearLines(c.lastHeight)
+		return
+	}
+	// best effort for a non-file writer (e.g. a plain network or buffer
+	// writer): move the cursor up to the start of the bar block, then
+	// clear everything from there to the end of the screen
+	fmt.Fprintf(c.writer, "\033[%dA\033[J", c.lastHeight)
+}
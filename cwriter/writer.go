@@ -0,0 +1,57 @@
+// Package cwriter writes to a console, clearing and repositioning lines it
+// previously wrote so a caller can redraw them in place. On POSIX it emits
+// ANSI escape sequences; on Windows, where legacy consoles such as cmd.exe
+// don't interpret those sequences, it uses the native console APIs instead.
+package cwriter
+
+import (
+	"io"
+	"os"
+)
+
+// Writer clears and rewrites lines of console output. Its methods are
+// no-ops when it wraps something other than an *os.File; any *os.File,
+// including one redirected to a file or pipe, is treated as a console.
+type Writer struct {
+	out *os.File
+}
+
+// New wraps out. If out isn't an *os.File, the returned Writer's methods
+// are no-ops.
+func New(out io.Writer) *Writer {
+	f, _ := out.(*os.File)
+	return &Writer{out: f}
+}
+
+// ClearLines moves the cursor up n lines, to the start of a block of n
+// lines previously written, and clears everything from there to the end of
+// the screen.
+func (w *Writer) ClearLines(n int) error {
+	if w.out == nil || n <= 0 {
+		return nil
+	}
+	return clearLines(w.out, n)
+}
+
+// ClearLine clears the current line and returns the cursor to its start,
+// without moving the cursor up.
+func (w *Writer) ClearLine() error {
+	if w.out == nil {
+		return nil
+	}
+	return clearLine(w.out)
+}
+
+// WriteString writes str to the console.
+func (w *Writer) WriteString(str string) error {
+	if w.out == nil {
+		return nil
+	}
+	if _, err := io.WriteString(w.out, str); err != nil {
+		return err
+	}
+	// ignore any errors in Sync(), as stdout can't be synced on some
+	// operating systems like Debian 9 (Stretch)
+	w.out.Sync()
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package cwriter
+
+import (
+	"fmt"
+	"os"
+)
+
+// clearLines moves the cursor up n lines and clears from there to the end
+// of the screen using ANSI escape sequences, which any VT100-compatible
+// terminal understands.
+func clearLines(f *os.File, n int) error {
+	_, err := fmt.Fprintf(f, "\033[%dA\033[J", n)
+	return err
+}
+
+// clearLine clears the current line and returns the cursor to its start.
+func clearLine(f *os.File) error {
+	_, err := fmt.Fprint(f, "\033[2K\r")
+	return err
+}
@@ -0,0 +1,80 @@
+//go:build windows
+// +build windows
+
+package cwriter
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// FillConsoleOutputCharacterW isn't wrapped by golang.org/x/sys/windows, so
+// it's bound here the same way mpb/uilive's console writers do.
+var (
+	modkernel32                     = windows.NewLazySystemDLL("kernel32.dll")
+	procFillConsoleOutputCharacterW = modkernel32.NewProc("FillConsoleOutputCharacterW")
+)
+
+func fillConsoleOutputCharacter(h windows.Handle, char rune, cells uint32, origin windows.Coord) error {
+	// COORD is passed by value as a single 32-bit word: X in the low
+	// 16 bits, Y in the high 16 bits.
+	coord := uintptr(uint16(origin.X)) | uintptr(uint16(origin.Y))<<16
+
+	var written uint32
+	ret, _, err := procFillConsoleOutputCharacterW.Call(
+		uintptr(h),
+		uintptr(uint16(char)),
+		uintptr(cells),
+		coord,
+		uintptr(unsafe.Pointer(&written)),
+	)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// clearLines moves the cursor up n lines and blanks the region from there
+// to the end of the console's screen buffer, using the native console
+// APIs. Legacy Windows consoles (cmd.exe without VT processing enabled)
+// don't honor ANSI escape sequences, so this is the only reliable way to
+// clear a block of previously-written lines there.
+func clearLines(f *os.File, n int) error {
+	h := windows.Handle(f.Fd())
+
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(h, &info); err != nil {
+		return err
+	}
+
+	origin := windows.Coord{X: 0, Y: info.CursorPosition.Y - int16(n)}
+	if origin.Y < 0 {
+		origin.Y = 0
+	}
+
+	if err := windows.SetConsoleCursorPosition(h, origin); err != nil {
+		return err
+	}
+
+	cells := uint32(info.Size.X) * uint32(info.Size.Y-origin.Y)
+	return fillConsoleOutputCharacter(h, ' ', cells, origin)
+}
+
+// clearLine blanks the current line and returns the cursor to its start.
+func clearLine(f *os.File) error {
+	h := windows.Handle(f.Fd())
+
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(h, &info); err != nil {
+		return err
+	}
+
+	origin := windows.Coord{X: 0, Y: info.CursorPosition.Y}
+	if err := windows.SetConsoleCursorPosition(h, origin); err != nil {
+		return err
+	}
+
+	return fillConsoleOutputCharacter(h, ' ', uint32(info.Size.X), origin)
+}
@@ -0,0 +1,125 @@
+// Package decor provides composable pieces of text ("decorators") that can
+// be arranged around a progressbar.ProgressBar's bar via
+// progressbar.OptionPrependDecorators and progressbar.OptionAppendDecorators,
+// modeled after the decor package in vbauerster/mpb.
+//
+// A Decorator does not know about the bar it is attached to; it is handed a
+// State snapshot on every render and returns the text to display. This
+// keeps the built-ins here free of any dependency on the progressbar
+// package, and makes it straightforward to write custom decorators (e.g.
+// per-file transfer speed) without forking the bar's render loop.
+package decor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// State is a snapshot of a progress bar's counters and timing, passed to a
+// Decorator on every render.
+type State struct {
+	Current   int64
+	Total     int64
+	Percent   float64
+	StartTime time.Time
+	// Rate is the current smoothed rate of progress, in units (or bytes)
+	// per second.
+	Rate float64
+	// Completed reports whether the bar has reached its max.
+	Completed bool
+}
+
+// Decorator renders a piece of text from a State.
+type Decorator interface {
+	Decorate(State) string
+}
+
+// Func adapts a plain function to the Decorator interface.
+type Func func(State) string
+
+// Decorate implements Decorator.
+func (f Func) Decorate(s State) string {
+	return f(s)
+}
+
+// Name renders a fixed label, e.g. a description or filename.
+func Name(name string) Decorator {
+	return Func(func(State) string {
+		return name
+	})
+}
+
+// Percentage renders the current percentage, e.g. " 42%".
+func Percentage() Decorator {
+	return Func(func(s State) string {
+		return fmt.Sprintf("%3.0f%%", s.Percent)
+	})
+}
+
+// CountersNoUnit renders "current/total" with no unit suffix.
+func CountersNoUnit() Decorator {
+	return Func(func(s State) string {
+		return fmt.Sprintf("%d/%d", s.Current, s.Total)
+	})
+}
+
+// CountersKibiByte renders "current/total" humanized using binary
+// (1024-based) units labelled KB/MB/..., e.g. "3.4 MB/10 MB".
+func CountersKibiByte() Decorator {
+	return Func(func(s State) string {
+		cur, curSuffix := humanizeBytes(float64(s.Current))
+		tot, totSuffix := humanizeBytes(float64(s.Total))
+		return fmt.Sprintf("%s%s/%s%s", cur, curSuffix, tot, totSuffix)
+	})
+}
+
+// Elapsed renders the time since the bar started.
+func Elapsed() Decorator {
+	return Func(func(s State) string {
+		return (time.Duration(time.Since(s.StartTime).Seconds()) * time.Second).String()
+	})
+}
+
+// EWMA renders the estimated time remaining, derived from the State's
+// smoothed Rate (see progressbar's OptionEWMAAge).
+func EWMA() Decorator {
+	return Func(func(s State) string {
+		if s.Rate <= 0 {
+			return "?"
+		}
+		remaining := float64(s.Total - s.Current)
+		eta := time.Duration(remaining/s.Rate) * time.Second
+		if eta < 0 {
+			eta = 0
+		}
+		return eta.String()
+	})
+}
+
+// OnComplete wraps decorator so that once the bar is completed, replacement
+// is rendered instead.
+func OnComplete(decorator Decorator, replacement string) Decorator {
+	return Func(func(s State) string {
+		if s.Completed {
+			return replacement
+		}
+		return decorator.Decorate(s)
+	})
+}
+
+func humanizeBytes(s float64) (string, string) {
+	sizes := []string{" B", " KB", " MB", " GB", " TB", " PB", " EB"}
+	base := 1024.0
+	if s < 10 {
+		return fmt.Sprintf("%2.0f", s), sizes[0]
+	}
+	e := math.Floor(math.Log(s) / math.Log(base))
+	suffix := sizes[int(e)]
+	val := math.Floor(s/math.Pow(base, e)*10+0.5) / 10
+	f := "%.0f"
+	if val < 10 {
+		f = "%.1f"
+	}
+	return fmt.Sprintf(f, val), suffix
+}
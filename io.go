@@ -0,0 +1,78 @@
+package progressbar
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultBytes provides a progress bar with recommended defaults for
+// tracking the progress of a byte stream, e.g. an HTTP download or file
+// copy driven through Reader or Write. Set max to -1 to use as a spinner
+// when the total size is unknown.
+func DefaultBytes(max int64, description ...string) *ProgressBar {
+	desc := ""
+	if len(description) > 0 {
+		desc = description[0]
+	}
+
+	return NewOptions64(
+		max,
+		OptionSetDescription(desc),
+		OptionSetWriter(os.Stderr),
+		OptionShowBytes(true),
+		OptionSetWidth(10),
+		OptionThrottle(100*time.Millisecond),
+		OptionShowCount(),
+		OptionOnCompletion(func() {
+			fmt.Fprint(os.Stderr, "\n")
+		}),
+		OptionSpinnerType(14),
+		OptionFullWidth(),
+		OptionSetRenderBlankState(true),
+	)
+}
+
+// Write implements io.Writer, so the progress bar itself can be passed
+// anywhere a writer is expected, e.g. io.MultiWriter(f, bar). Every byte
+// written is counted as progress.
+func (p *ProgressBar) Write(b []byte) (n int, err error) {
+	n = len(b)
+	err = p.Add(n)
+	return
+}
+
+// Read implements io.Reader, advancing the bar by len(b) without touching
+// the contents of b. It exists so a *ProgressBar can satisfy io.Reader
+// directly; most callers want Reader instead, which wraps an existing
+// io.Reader and advances the bar by the bytes actually read from it.
+func (p *ProgressBar) Read(b []byte) (n int, err error) {
+	n = len(b)
+	err = p.Add(n)
+	return
+}
+
+// progressBarReader wraps an io.Reader, advancing its ProgressBar by the
+// number of bytes read from the underlying reader on every call.
+type progressBarReader struct {
+	io.Reader
+	bar *ProgressBar
+}
+
+func (r *progressBarReader) Read(b []byte) (n int, err error) {
+	n, err = r.Reader.Read(b)
+	if addErr := r.bar.Add(n); addErr != nil && err == nil {
+		err = addErr
+	}
+	return
+}
+
+// Reader wraps r so that reading from it advances bar, letting transfers
+// be tracked transparently, e.g.:
+//
+//	bar := progressbar.DefaultBytes(resp.ContentLength)
+//	io.Copy(f, bar.Reader(resp.Body))
+func (p *ProgressBar) Reader(r io.Reader) io.Reader {
+	return &progressBarReader{Reader: r, bar: p}
+}
@@ -1,6 +1,7 @@
 package progressbar
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -14,12 +15,27 @@ import (
 	"github.com/mattn/go-runewidth"
 	"github.com/mitchellh/colorstring"
 	"golang.org/x/term"
+
+	"github.com/theone-daxia/progressbar/cwriter"
+	"github.com/theone-daxia/progressbar/decor"
 )
 
 type ProgressBar struct {
 	state  state
 	config config
 	lock   sync.Mutex
+
+	// done is closed once the bar finishes or is aborted, so the
+	// OptionContext watcher goroutine can exit without waiting for ctx
+	// to be canceled.
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// closeDone closes p.done, if it hasn't been already. Safe to call more
+// than once or concurrently.
+func (p *ProgressBar) closeDone() {
+	p.doneOnce.Do(func() { close(p.done) })
 }
 
 // State is the basic properties of the bar
@@ -45,6 +61,11 @@ type state struct {
 	counterNumSinceLast int64
 	counterLastTenRates []float64
 
+	// ewmaRate and ewmaLastTime back the exponentially-weighted moving
+	// average used to predict the finishing time; see AverageStrategy.
+	ewmaRate     float64
+	ewmaLastTime time.Time
+
 	maxLineWidth int
 	currentBytes float64
 	finished     bool
@@ -90,17 +111,41 @@ type config struct {
 
 	onCompletion func()
 
+	// ctx, if set via OptionContext, is watched for cancellation so the
+	// bar can be aborted from outside its own goroutine.
+	ctx context.Context
+
+	// onAbort is invoked when the bar is stopped early via Abort or ctx
+	// cancellation, distinct from onCompletion which only fires when the
+	// bar reaches max.
+	onAbort func()
+
 	// fullWidth specifies whether to measure and set the bar to a specific width
 	fullWidth bool
 
 	// whether the render function should make use of ANSI codes to reduce console I/O
 	useANSICodes bool
 
+	// averageStrategy controls how the rate used for the predicted
+	// finishing time is computed.
+	averageStrategy AverageStrategy
+
+	// ewmaAge is the tau used when averageStrategy is StrategyEWMA: how
+	// quickly old samples decay out of the average.
+	ewmaAge time.Duration
+
 	// clear bar once finished
 	clearOnFinish bool
 
 	// whether the output is expected to contain color codes
 	colorCodes bool
+
+	// prependDecorators and appendDecorators render additional text
+	// around the bar itself, in the order they were given. They don't
+	// replace the legacy showBytes/showIterationsCount/predictTime
+	// output, which keeps working for callers who never opt in to them.
+	prependDecorators []decor.Decorator
+	appendDecorators  []decor.Decorator
 }
 
 // Theme defines the elements of the bar
@@ -181,6 +226,23 @@ func OptionOnCompletion(cmpl func()) Option {
 	}
 }
 
+// OptionOnAbort will invoke f if the bar is stopped early via Abort or
+// the context passed to OptionContext being canceled. It is never called
+// on normal completion; use OptionOnCompletion for that.
+func OptionOnAbort(f func()) Option {
+	return func(p *ProgressBar) {
+		p.config.onAbort = f
+	}
+}
+
+// OptionContext ties the bar's lifetime to ctx: when ctx is canceled, the
+// bar behaves as if Abort(true) had been called.
+func OptionContext(ctx context.Context) Option {
+	return func(p *ProgressBar) {
+		p.config.ctx = ctx
+	}
+}
+
 // OptionFullWidth sets the bar to be full width
 func OptionFullWidth() Option {
 	return func(p *ProgressBar) {
@@ -203,6 +265,57 @@ func OptionEnableColorCodes(colorCodes bool) Option {
 	}
 }
 
+// AverageStrategy selects how the rate used for the predicted finishing
+// time is computed.
+type AverageStrategy int
+
+const (
+	// StrategyEWMA predicts the finishing time from an exponentially-
+	// weighted moving average of the rate, which reacts faster to bursty
+	// workloads than StrategyArithmeticMean. This is the default.
+	StrategyEWMA AverageStrategy = iota
+	// StrategyArithmeticMean predicts the finishing time from the mean
+	// rate over the last few half-second buckets, matching the bar's
+	// original behavior.
+	StrategyArithmeticMean
+)
+
+// OptionAverageStrategy sets how the predicted finishing time is computed.
+// The default is StrategyEWMA; pass StrategyArithmeticMean for the bar's
+// original rolling-average behavior.
+func OptionAverageStrategy(s AverageStrategy) Option {
+	return func(p *ProgressBar) {
+		p.config.averageStrategy = s
+	}
+}
+
+// OptionEWMAAge sets tau for the exponentially-weighted moving average used
+// by StrategyEWMA: roughly, samples older than d contribute little to the
+// current rate. The default is 30 seconds.
+func OptionEWMAAge(d time.Duration) Option {
+	return func(p *ProgressBar) {
+		p.config.ewmaAge = d
+	}
+}
+
+// OptionPrependDecorators renders decorators, in order, at the start of the
+// line, before the description and percentage. They are appended to any
+// decorators already configured.
+func OptionPrependDecorators(decorators ...decor.Decorator) Option {
+	return func(p *ProgressBar) {
+		p.config.prependDecorators = append(p.config.prependDecorators, decorators...)
+	}
+}
+
+// OptionAppendDecorators renders decorators, in order, at the end of the
+// line, after the counters, time and description. They are appended to any
+// decorators already configured.
+func OptionAppendDecorators(decorators ...decor.Decorator) Option {
+	return func(p *ProgressBar) {
+		p.config.appendDecorators = append(p.config.appendDecorators, decorators...)
+	}
+}
+
 var defaultTheme = Theme{Saucer: "█", SaucerPadding: " ", BarStart: "|", BarEnd: "|"}
 
 func NewOptions64(max int64, options ...Option) *ProgressBar {
@@ -217,7 +330,10 @@ func NewOptions64(max int64, options ...Option) *ProgressBar {
 			elapsedTime:      true,
 			predictTime:      true,
 			spinnerType:      9,
+			averageStrategy:  StrategyEWMA,
+			ewmaAge:          30 * time.Second,
 		},
+		done: make(chan struct{}),
 	}
 
 	for _, o := range options {
@@ -241,6 +357,18 @@ func NewOptions64(max int64, options ...Option) *ProgressBar {
 		b.RenderBlank()
 	}
 
+	if b.config.ctx != nil {
+		go func() {
+			select {
+			case <-b.config.ctx.Done():
+				b.Abort(true) // nolint:errcheck
+			case <-b.done:
+				// bar finished or was aborted on its own; nothing left
+				// to watch ctx for
+			}
+		}()
+	}
+
 	return &b
 }
 
@@ -312,6 +440,23 @@ func (p *ProgressBar) Add64(num int64) error {
 
 	p.state.currentBytes += float64(num)
 
+	// update the exponentially-weighted moving average used by
+	// StrategyEWMA; seed it with the first sample instead of smoothing
+	// towards zero
+	if num > 0 {
+		now := time.Now()
+		if p.state.ewmaLastTime.IsZero() {
+			if elapsed := now.Sub(p.state.startTime).Seconds(); elapsed > 0 {
+				p.state.ewmaRate = float64(num) / elapsed
+			}
+		} else if dt := now.Sub(p.state.ewmaLastTime).Seconds(); dt > 0 {
+			instantRate := float64(num) / dt
+			alpha := 1 - math.Exp(-dt/p.config.ewmaAge.Seconds())
+			p.state.ewmaRate = alpha*instantRate + (1-alpha)*p.state.ewmaRate
+		}
+		p.state.ewmaLastTime = now
+	}
+
 	// reset the countdown timer every second to take rolling average
 	p.state.counterNumSinceLast += num
 	if time.Since(p.state.counterTime).Seconds() > 0.5 {
@@ -349,6 +494,34 @@ func (p *ProgressBar) RenderBlank() error {
 	return p.render()
 }
 
+// Abort stops the bar before it reaches max, e.g. because the operation it
+// was tracking failed. Once aborted, Add and Add64 become no-ops. If clear
+// is true the bar's line is erased, the same as when clearOnFinish is set
+// for a normal completion. OptionOnAbort, if set, is invoked after the
+// line is cleared.
+func (p *ProgressBar) Abort(clear bool) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.state.exit {
+		return nil
+	}
+	p.state.exit = true
+	p.closeDone()
+
+	if clear {
+		if err := clearProgressBar(p.config, p.state); err != nil {
+			return err
+		}
+	}
+
+	if p.config.onAbort != nil {
+		p.config.onAbort()
+	}
+
+	return nil
+}
+
 // Render renders the progress bar, updating the maximum
 // rendered line width. this function is not thread-safe,
 // so it must be called with an acquired lock.
@@ -371,6 +544,7 @@ func (p *ProgressBar) render() error {
 	// check if the progress bar is finished
 	if !p.state.finished && p.state.currentNum >= p.config.max {
 		p.state.finished = true
+		p.closeDone()
 		if !p.config.clearOnFinish {
 			renderProgressBar(p.config, &p.state)
 		}
@@ -411,6 +585,11 @@ func clearProgressBar(c config, s state) error {
 		return nil
 	}
 	if c.useANSICodes {
+		// route through cwriter so this also works on legacy Windows
+		// consoles, which don't interpret the ANSI sequence below
+		if f, ok := c.writer.(*os.File); ok {
+			return cwriter.New(f).ClearLine()
+		}
 		// write the "clear current line" ANSI escape sequence
 		return writeString(c, "\033[2K\r")
 	}
@@ -422,15 +601,12 @@ func clearProgressBar(c config, s state) error {
 }
 
 func writeString(c config, str string) error {
-	if _, err := io.WriteString(c.writer, str); err != nil {
-		return err
+	if f, ok := c.writer.(*os.File); ok {
+		return cwriter.New(f).WriteString(str)
 	}
 
-	if f, ok := c.writer.(*os.File); ok {
-		// ignore any errors in Sync(), as stdout
-		// can't be synced on some operating systems
-		// like Debian 9 (Stretch)
-		f.Sync()
+	if _, err := io.WriteString(c.writer, str); err != nil {
+		return err
 	}
 
 	return nil
@@ -439,15 +615,54 @@ func writeString(c config, str string) error {
 func renderProgressBar(c config, s *state) (int, error) {
 	var sb strings.Builder
 
-	averageRate := average(s.counterLastTenRates)
-	if len(s.counterLastTenRates) == 0 || s.finished {
-		// if no average samples, or if finished,
-		// then average rate should be the total rate.
-		if t := time.Since(s.startTime).Seconds(); t > 0 {
-			averageRate = s.currentBytes / t
-		} else {
-			averageRate = 0
+	var averageRate float64
+	switch c.averageStrategy {
+	case StrategyArithmeticMean:
+		averageRate = average(s.counterLastTenRates)
+		if len(s.counterLastTenRates) == 0 || s.finished {
+			// if no average samples, or if finished,
+			// then average rate should be the total rate.
+			if t := time.Since(s.startTime).Seconds(); t > 0 {
+				averageRate = s.currentBytes / t
+			} else {
+				averageRate = 0
+			}
 		}
+	default: // StrategyEWMA
+		averageRate = s.ewmaRate
+		if s.finished {
+			// once finished, report the true average rather than
+			// whatever the moving average had settled on
+			if t := time.Since(s.startTime).Seconds(); t > 0 {
+				averageRate = s.currentBytes / t
+			}
+		}
+	}
+
+	// render decorator groups up front so their width can be subtracted
+	// from the bar's fullWidth sizing below, and so they're available to
+	// splice in once the rest of the line is built.
+	var decorPrepend, decorAppend string
+	if len(c.prependDecorators) > 0 || len(c.appendDecorators) > 0 {
+		ds := decor.State{
+			Current:   s.currentNum,
+			Total:     c.max,
+			Percent:   float64(s.currentPercent),
+			StartTime: s.startTime,
+			Rate:      averageRate,
+			Completed: s.finished,
+		}
+
+		var prepend, appendStr strings.Builder
+		for _, d := range c.prependDecorators {
+			prepend.WriteString(d.Decorate(ds))
+			prepend.WriteString(" ")
+		}
+		for _, d := range c.appendDecorators {
+			appendStr.WriteString(" ")
+			appendStr.WriteString(d.Decorate(ds))
+		}
+		decorPrepend, decorAppend = prepend.String(), appendStr.String()
 	}
 
 	// show iteration count in "current/total" iterations format
@@ -532,7 +747,7 @@ func renderProgressBar(c config, s *state) (int, error) {
 			amend += 1 // another space
 		}
 
-		c.width = width - getStringWidth(c, c.description, true) - 10 - amend - sb.Len() - len(leftBrac) - len(rightBrac)
+		c.width = width - getStringWidth(c, c.description, true) - 10 - amend - sb.Len() - len(leftBrac) - len(rightBrac) - len(decorPrepend) - len(decorAppend)
 		s.currentSaucerSize = int(float64(s.currentPercent) / 100.0 * float64(c.width))
 	}
 	if s.currentSaucerSize > 0 {
@@ -645,6 +860,10 @@ func renderProgressBar(c config, s *state) (int, error) {
 		}
 	}
 
+	if decorPrepend != "" || decorAppend != "" {
+		str = "\r" + decorPrepend + strings.TrimPrefix(str, "\r") + decorAppend
+	}
+
 	if c.colorCodes {
 		// convert any color codes in the progress bar into the respective ANSI codes
 		str = colorstring.Color(str)